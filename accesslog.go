@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// loggingResponseWriter wraps a ResponseWriter to capture the status
+// code and number of bytes written, for AccessLog.
+type loggingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *loggingResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *loggingResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// AccessLog returns a middleware, for use with Use, that emits one
+// Apache combined-format log line per request via Logger.Infof.
+func (api *Api) AccessLog() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lw := &loggingResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			start := time.Now()
+
+			// defer so the line is still logged when the handler panics
+			// and the panic unwinds past us to an outer Recover
+			// middleware (NewApi installs Recover first, so it is
+			// outermost). The status logged then is the one written
+			// before the panic, 200 if none was.
+			defer func() {
+				api.Logger.Infof("%s", combinedLogLine(r, lw.status, lw.bytes, start))
+			}()
+
+			next.ServeHTTP(lw, r)
+		})
+	}
+}
+
+// combinedLogLine formats r/status/bytes as an Apache combined log
+// format entry: `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"`.
+func combinedLogLine(r *http.Request, status, bytes int, t time.Time) string {
+	host := r.RemoteAddr
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	user := "-"
+
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = u
+	}
+
+	size := "-"
+
+	if bytes > 0 {
+		size = strconv.Itoa(bytes)
+	}
+
+	referer := r.Referer()
+
+	if referer == "" {
+		referer = "-"
+	}
+
+	userAgent := r.UserAgent()
+
+	if userAgent == "" {
+		userAgent = "-"
+	}
+
+	return fmt.Sprintf(`%s - %s [%s] "%s %s %s" %d %s "%s" "%s"`,
+		host, user, t.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method, r.URL.RequestURI(), r.Proto, status, size, referer, userAgent)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// fakeLogger records every call so tests can assert on it without a
+// real log sink.
+type fakeLogger struct {
+	lines []string
+}
+
+func (l *fakeLogger) Debugf(format string, args ...interface{}) { l.record(format, args...) }
+func (l *fakeLogger) Infof(format string, args ...interface{})  { l.record(format, args...) }
+func (l *fakeLogger) Errorf(format string, args ...interface{}) { l.record(format, args...) }
+
+func (l *fakeLogger) record(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestAccessLogLineIsAnApacheCombinedFormatLine(t *testing.T) {
+	api := NewApi(nil)
+	logger := &fakeLogger{}
+	api.Logger = logger
+
+	h := api.AccessLog()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("ok"))
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r.RemoteAddr = "203.0.113.1:54321"
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	if len(logger.lines) != 1 {
+		t.Fatalf("expected 1 log line, got %d: %v", len(logger.lines), logger.lines)
+	}
+
+	re := regexp.MustCompile(`^203\.0\.113\.1 - - \[.+\] "GET /users HTTP/1\.1" 201 2 "-" "-"$`)
+
+	if !re.MatchString(logger.lines[0]) {
+		t.Errorf("log line %q does not match apache combined format", logger.lines[0])
+	}
+}
+
+func TestAccessLogStillLogsWhenHandlerPanics(t *testing.T) {
+	api := NewApi(nil)
+	logger := &fakeLogger{}
+	api.Logger = logger
+
+	h := api.AccessLog()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	// api.Recover wraps AccessLog the same way NewApi/Use orders them
+	// in practice: Recover outermost, AccessLog inside it.
+	full := api.Recover()(h)
+
+	r := httptest.NewRequest(http.MethodGet, "/panics", nil)
+	w := httptest.NewRecorder()
+
+	full.ServeHTTP(w, r)
+
+	if len(logger.lines) == 0 {
+		t.Fatal("expected an access-log line even though the handler panicked")
+	}
+}
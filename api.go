@@ -1,12 +1,10 @@
 package api
 
 import (
-	"encoding/json"
-	"log"
+	"context"
 	"net"
 	"net/http"
-	"os"
-	"os/signal"
+	"sync"
 	"time"
 )
 
@@ -38,132 +36,222 @@ type Api struct {
 	ReadTimeout    time.Duration
 	WriteTimeout   time.Duration
 	MaxHeaderBytes int
+
+	// BeforeDispatch runs before every handler registered via
+	// Get/Post/Put/Delete. It may substitute w/r (e.g. wrap w in an
+	// access-log writer) and can short-circuit the request by writing
+	// a response itself and returning handled=true.
+	BeforeDispatch BeforeDispatchFunc
+	// AfterDispatch runs after the handler, unless BeforeDispatch
+	// short-circuited the request.
+	AfterDispatch AfterDispatchFunc
+
+	middlewares []func(http.Handler) http.Handler
+
+	// Serializers are the Serializer implementations registered on
+	// this Api, keyed by the media type they produce. Register
+	// additional ones with RegisterSerializer.
+	Serializers map[string]Serializer
+
+	// Logger receives Api's internal log output. Defaults to a
+	// stdLogger writing to os.Stderr; set it before use to plug in
+	// zap, zerolog, etc.
+	Logger Logger
+
+	// OnPanic, if set, is called by the default Recover middleware with
+	// the recovered value and stack trace of every panic, e.g. to
+	// report it to Sentry/Rollbar.
+	OnPanic func(interface{}, []byte)
+
+	serverMu sync.Mutex
+	server   *http.Server
 }
 
 func NewApi(router Router) *Api {
-	api := &Api{Router: router, Config: Config{}}
+	api := &Api{Router: router, Config: Config{}, Logger: newStdLogger()}
+
+	api.RegisterSerializer(MediaTypeJSON, &JSONSerializer{})
+	api.RegisterSerializer(MediaTypeMessagePack, &MessagePackSerializer{})
+	api.RegisterSerializer(MediaTypeProtobuf, &ProtobufSerializer{})
+
+	api.Use(api.Recover())
+
 	return api
 }
 
+// Use appends mw to the middleware chain that wraps handlers
+// registered via Get/Post/Put/Delete. Middlewares run in the order they
+// were added, outermost first. The chain is fixed when a handler is
+// registered, so handlers registered before this call are not wrapped
+// by mw.
+func (api *Api) Use(mw func(http.Handler) http.Handler) {
+	api.middlewares = append(api.middlewares, mw)
+}
+
 // --- routing helper ---
 
 func (api *Api) Get(path string, f HandlerFunc) {
-	api.Router.Get(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "apilication/json; charset=utf-8")
-		f(w, r)
-	}))
+	api.Router.Get(path, api.dispatch(f))
 }
 
 func (api *Api) Post(path string, f HandlerFunc) {
-	api.Router.Post(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "apilication/json; charset=utf-8")
-		f(w, r)
-	}))
+	api.Router.Post(path, api.dispatch(f))
 }
 
 func (api *Api) Put(path string, f HandlerFunc) {
-	api.Router.Put(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "apilication/json; charset=utf-8")
-		f(w, r)
-	}))
+	api.Router.Put(path, api.dispatch(f))
 }
 
 func (api *Api) Delete(path string, f HandlerFunc) {
-	api.Router.Delete(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "apilication/json; charset=utf-8")
-		f(w, r)
-	}))
+	api.Router.Delete(path, api.dispatch(f))
+}
+
+// dispatch wraps f with the BeforeDispatch/AfterDispatch hooks and the
+// middleware chain registered via Use, so every verb helper shares the
+// same content-type, logging and recovery behavior instead of
+// duplicating it.
+func (api *Api) dispatch(f HandlerFunc) http.Handler {
+	h := http.Handler(http.HandlerFunc(f))
+
+	for i := len(api.middlewares) - 1; i >= 0; i-- {
+		h = api.middlewares[i](h)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", api.negotiate(r).ContentType())
+
+		before := api.BeforeDispatch
+		if before == nil {
+			before = DefaultBeforeDispatch
+		}
+
+		var handled bool
+		w, r, handled = before(w, r)
+
+		if handled {
+			return
+		}
+
+		h.ServeHTTP(w, r)
+
+		after := api.AfterDispatch
+		if after == nil {
+			after = DefaultAfterDispatch
+		}
+
+		after(w, r)
+	})
 }
 
 // --- error helper ---
 
 // write `{message: "error content"}` with http-status-code:http.StatusInternalServerError
-func (api *Api) Error(w http.ResponseWriter, err error) {
-	api.ErrorWithHttpStatusAndApiStatus(w, err, http.StatusInternalServerError, 0)
+func (api *Api) Error(w http.ResponseWriter, r *http.Request, err error) error {
+	return api.ErrorWithHttpStatusAndApiStatus(w, r, err, http.StatusInternalServerError, 0)
 }
 
 // write `{message: "error content"}` with http-status-code
-func (api *Api) ErrorWithHttpStatus(w http.ResponseWriter, err error, httpStatus int) {
-	api.ErrorWithHttpStatusAndApiStatus(w, err, httpStatus, 0)
+func (api *Api) ErrorWithHttpStatus(w http.ResponseWriter, r *http.Request, err error, httpStatus int) error {
+	return api.ErrorWithHttpStatusAndApiStatus(w, r, err, httpStatus, 0)
 }
 
-// write `{message: "error content"}` with http-status-code and api-status-code
-func (api *Api) ErrorWithHttpStatusAndApiStatus(w http.ResponseWriter, err error, httpStatus, apiStatus int) {
-	log.Print(err.Error())
+// write `{message: "error content"}` with http-status-code and api-status-code,
+// encoded with the Serializer negotiated from r's Accept header,
+// falling back to JSON if that Serializer can't encode the body. It
+// returns the marshal error instead of panicking, so a broken
+// Serializer cannot crash the process.
+func (api *Api) ErrorWithHttpStatusAndApiStatus(w http.ResponseWriter, r *http.Request, err error, httpStatus, apiStatus int) error {
+	api.Logger.Errorf("%s", err.Error())
 
-	j, marchalError := json.Marshal(&APIError{Message: err.Error(), ApiStatus: apiStatus})
+	j, s, marchalError := api.marshalBody(api.negotiate(r), &APIError{Message: err.Error(), ApiStatus: apiStatus})
 
 	if marchalError != nil {
-		panic(marchalError)
+		return marchalError
 	}
 
-	w.Header().Set("Content-Type", "apilication/json; charset=utf-8")
+	w.Header().Set("Content-Type", s.ContentType())
 	http.Error(w, string(j), httpStatus)
+
+	return nil
 }
 
 // write `{errors: [{message: "error content"}, {message: "error content"}]}` with http-status-code:http.StatusInternalServerError
-func (api *Api) Errors(w http.ResponseWriter, errs []error) {
-	api.ErrorsWithHttpStatusAndApiStatus(w, errs, http.StatusInternalServerError, 0)
+func (api *Api) Errors(w http.ResponseWriter, r *http.Request, errs []error) error {
+	return api.ErrorsWithHttpStatusAndApiStatus(w, r, errs, http.StatusInternalServerError, 0)
 }
 
 // write `{errors: [{message: "error content"}, {message: "error content"}]}` with http-status-code
-func (api *Api) ErrorsWithHttpStatus(w http.ResponseWriter, errs []error, httpStatus int) {
-	api.ErrorsWithHttpStatusAndApiStatus(w, errs, httpStatus, 0)
+func (api *Api) ErrorsWithHttpStatus(w http.ResponseWriter, r *http.Request, errs []error, httpStatus int) error {
+	return api.ErrorsWithHttpStatusAndApiStatus(w, r, errs, httpStatus, 0)
 }
 
-// write `{errors: [{message: "error content"}, {message: "error content"}]}` with http-status-code and api-status-code
-func (api *Api) ErrorsWithHttpStatusAndApiStatus(w http.ResponseWriter, errs []error, httpStatus, apiStatus int) {
+// write `{errors: [{message: "error content"}, {message: "error content"}]}` with http-status-code and api-status-code,
+// encoded with the Serializer negotiated from r's Accept header,
+// falling back to JSON if that Serializer can't encode the body. It
+// returns the marshal error instead of panicking, so a broken
+// Serializer cannot crash the process.
+func (api *Api) ErrorsWithHttpStatusAndApiStatus(w http.ResponseWriter, r *http.Request, errs []error, httpStatus, apiStatus int) error {
 	apiErrors := &APIErrors{ApiStatus: apiStatus}
 
 	for _, err := range errs {
-		log.Print(err.Error())
+		api.Logger.Errorf("%s", err.Error())
 		apiErrors.Errors = append(apiErrors.Errors, &APIError{Message: err.Error()})
 	}
 
-	j, marchalError := json.Marshal(apiErrors)
+	j, s, marchalError := api.marshalBody(api.negotiate(r), apiErrors)
 
 	if marchalError != nil {
-		panic(marchalError)
+		return marchalError
 	}
 
-	w.Header().Set("Content-Type", "apilication/json; charset=utf-8")
+	w.Header().Set("Content-Type", s.ContentType())
 	http.Error(w, string(j), httpStatus)
+
+	return nil
 }
 
 // --- server helper ---
 
-func (api *Api) Run(addr string) {
+// Run serves api.Router on l until the listener is closed or Stop is
+// called. Callers own the listener, so it can come from net.Listen,
+// socket-activation, server-starter, etc. Run blocks until Serve
+// returns, and returns nil if that happened because of a call to Stop.
+func (api *Api) Run(l net.Listener) error {
 	s := &http.Server{
-		Addr:           addr,
 		Handler:        api.Router,
 		ReadTimeout:    api.ReadTimeout,
 		WriteTimeout:   api.WriteTimeout,
 		MaxHeaderBytes: api.MaxHeaderBytes,
 	}
 
-	// notify signal Interrupt to channel c
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
+	api.serverMu.Lock()
+	api.server = s
+	api.serverMu.Unlock()
+
+	api.Logger.Infof("HTTP Server: %s", l.Addr())
 
-	listener, err := net.Listen("tcp", addr)
+	err := s.Serve(l)
 
-	if err != nil {
-		log.Fatalf("Could not listen: %s", addr)
+	if err == http.ErrServerClosed {
+		return nil
 	}
 
-	go func() {
-		for _ = range c {
-			// sig is a ^C, handle it
-			log.Print("Stopping the server...")
-			listener.Close()
+	return err
+}
 
-			log.Print("Tearing down...")
-			log.Fatal("Finished - bye bye.  ;-)")
+// Stop stops accepting new connections and waits for in-flight handlers
+// to finish, honoring ctx's deadline. It is safe to call from a signal
+// handler running alongside Run.
+func (api *Api) Stop(ctx context.Context) error {
+	api.serverMu.Lock()
+	s := api.server
+	api.serverMu.Unlock()
 
-		}
-	}()
+	if s == nil {
+		return nil
+	}
 
-	log.Printf("HTTP Server: %s", addr)
+	api.Logger.Infof("Stopping the server...")
 
-	log.Fatalf("Error in Serve: %s", s.Serve(listener))
+	return s.Shutdown(ctx)
 }
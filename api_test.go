@@ -0,0 +1,46 @@
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorLogsMessageContainingPercentVerbatim(t *testing.T) {
+	api := NewApi(nil)
+	logger := &fakeLogger{}
+	api.Logger = logger
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+
+	if err := api.Error(w, r, errors.New("value '50% off' invalid")); err != nil {
+		t.Fatalf("Error returned %v", err)
+	}
+
+	if len(logger.lines) != 1 {
+		t.Fatalf("expected 1 log line, got %d: %v", len(logger.lines), logger.lines)
+	}
+
+	if !strings.Contains(logger.lines[0], "50% off") {
+		t.Errorf("log line %q does not contain the original message; a literal %% was likely interpreted as a format verb", logger.lines[0])
+	}
+}
+
+func TestErrorsLogsMessageContainingPercentVerbatim(t *testing.T) {
+	api := NewApi(nil)
+	logger := &fakeLogger{}
+	api.Logger = logger
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+
+	if err := api.Errors(w, r, []error{errors.New("100% broken")}); err != nil {
+		t.Fatalf("Errors returned %v", err)
+	}
+
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], "100% broken") {
+		t.Errorf("log lines %v do not contain the original message verbatim", logger.lines)
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"log"
+	"os"
+)
+
+// Logger is the logging interface Api uses internally. Plug in zap,
+// zerolog, etc. by implementing it; stdlib users get stdLogger, built
+// on the standard library "log" package, by default.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// stdLogger is the default Logger, backed by the standard library
+// "log" package.
+type stdLogger struct {
+	*log.Logger
+}
+
+func newStdLogger() *stdLogger {
+	return &stdLogger{log.New(os.Stderr, "", log.LstdFlags)}
+}
+
+func (l *stdLogger) Debugf(format string, args ...interface{}) {
+	l.Printf(format, args...)
+}
+
+func (l *stdLogger) Infof(format string, args ...interface{}) {
+	l.Printf(format, args...)
+}
+
+func (l *stdLogger) Errorf(format string, args ...interface{}) {
+	l.Printf(format, args...)
+}
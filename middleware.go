@@ -0,0 +1,25 @@
+package api
+
+import "net/http"
+
+// BeforeDispatchFunc runs before a dispatched handler. It may return a
+// different ResponseWriter/Request (e.g. one wrapping w in an
+// access-log writer) and can short-circuit the request by writing a
+// response itself and returning handled=true, in which case the
+// handler and AfterDispatch are both skipped.
+type BeforeDispatchFunc func(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, bool)
+
+// AfterDispatchFunc runs after a dispatched handler, unless
+// BeforeDispatch short-circuited the request.
+type AfterDispatchFunc func(w http.ResponseWriter, r *http.Request)
+
+// DefaultBeforeDispatch is a no-op BeforeDispatchFunc used when Api's
+// BeforeDispatch field is nil.
+func DefaultBeforeDispatch(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, bool) {
+	return w, r, false
+}
+
+// DefaultAfterDispatch is a no-op AfterDispatchFunc used when Api's
+// AfterDispatch field is nil.
+func DefaultAfterDispatch(w http.ResponseWriter, r *http.Request) {
+}
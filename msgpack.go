@@ -0,0 +1,15 @@
+package api
+
+import "gopkg.in/vmihailenco/msgpack.v2"
+
+// MessagePackSerializer is the Serializer registered by default for
+// MediaTypeMessagePack.
+type MessagePackSerializer struct{}
+
+func (s *MessagePackSerializer) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (s *MessagePackSerializer) ContentType() string {
+	return MediaTypeMessagePack
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// ProtobufSerializer is the Serializer registered by default for
+// MediaTypeProtobuf. It only marshals values implementing
+// proto.Message.
+type ProtobufSerializer struct{}
+
+func (s *ProtobufSerializer) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+
+	if !ok {
+		return nil, errors.New("dou: value does not implement proto.Message")
+	}
+
+	return proto.Marshal(m)
+}
+
+func (s *ProtobufSerializer) ContentType() string {
+	return MediaTypeProtobuf
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover returns a middleware, installed by default on every Api,
+// that catches panics from downstream handlers and middlewares,
+// writes a 500 APIError through the negotiated serializer, and calls
+// OnPanic if set. An Api not created by NewApi has no Recover
+// installed; add it with Use.
+func (api *Api) Recover() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+
+				if rec == nil {
+					return
+				}
+
+				stack := debug.Stack()
+				api.Logger.Errorf("dou: recovered from panic: %v\n%s", rec, stack)
+
+				if api.OnPanic != nil {
+					api.OnPanic(rec, stack)
+				}
+
+				if err := api.ErrorWithHttpStatusAndApiStatus(w, r, fmt.Errorf("%v", rec), http.StatusInternalServerError, 0); err != nil {
+					api.Logger.Errorf("dou: failed to write panic response: %v", err)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const (
+	MediaTypeJSON        = "application/json"
+	MediaTypeMessagePack = "application/msgpack"
+	MediaTypeProtobuf    = "application/x-protobuf"
+)
+
+// Serializer encodes a value for the wire and reports the media type
+// it produces, so Api can negotiate on the request's Accept header
+// instead of hardcoding application/json in every verb helper.
+type Serializer interface {
+	Marshal(v interface{}) ([]byte, error)
+	ContentType() string
+}
+
+// JSONSerializer is the Serializer registered by default for
+// MediaTypeJSON.
+type JSONSerializer struct{}
+
+func (s *JSONSerializer) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (s *JSONSerializer) ContentType() string {
+	return MediaTypeJSON + "; charset=utf-8"
+}
+
+// RegisterSerializer registers s to be used when a request's Accept
+// header asks for mediaType.
+func (api *Api) RegisterSerializer(mediaType string, s Serializer) {
+	if api.Serializers == nil {
+		api.Serializers = map[string]Serializer{}
+	}
+
+	api.Serializers[mediaType] = s
+}
+
+// negotiate picks the Serializer matching r's Accept header, falling
+// back to the JSON serializer when Accept is absent, "*/*", or names a
+// media type with no registered Serializer.
+func (api *Api) negotiate(r *http.Request) Serializer {
+	accept := r.Header.Get("Accept")
+
+	if accept != "" && accept != "*/*" {
+		for _, mt := range strings.Split(accept, ",") {
+			mt = strings.TrimSpace(strings.SplitN(mt, ";", 2)[0])
+
+			if s, ok := api.Serializers[mt]; ok {
+				return s
+			}
+		}
+	}
+
+	if s, ok := api.Serializers[MediaTypeJSON]; ok {
+		return s
+	}
+
+	return &JSONSerializer{}
+}
+
+// marshalBody marshals v with s, falling back to JSON if s fails to
+// encode v (e.g. the negotiated Serializer is ProtobufSerializer and v
+// doesn't implement proto.Message) so the error helpers never leave w
+// untouched. It returns the Serializer that actually produced body,
+// so the caller sets the matching Content-Type.
+func (api *Api) marshalBody(s Serializer, v interface{}) (body []byte, used Serializer, err error) {
+	body, err = s.Marshal(v)
+
+	if err == nil {
+		return body, s, nil
+	}
+
+	if _, ok := s.(*JSONSerializer); ok {
+		return nil, s, err
+	}
+
+	js := &JSONSerializer{}
+
+	body, jsonErr := js.Marshal(v)
+
+	if jsonErr != nil {
+		return nil, s, err
+	}
+
+	return body, js, nil
+}
@@ -0,0 +1,66 @@
+package api
+
+import "net/http"
+
+// FieldError is a validation failure on a single field. *FieldError
+// implements error. ValidationError takes a []FieldError; it does not
+// accept or unwrap values of type error.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+	Params  map[string]interface{}
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// APIFieldError is the wire representation of a FieldError.
+type APIFieldError struct {
+	Field   string                 `json:"field"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// APIFieldErrors is the body written by ValidationError.
+type APIFieldErrors struct {
+	ApiStatus int              `json:"api_status"`
+	Errors    []*APIFieldError `json:"errors"`
+}
+
+// write `{api_status, errors: [{field, code, message, params}, ...]}` with http-status-code
+func (api *Api) ValidationError(w http.ResponseWriter, r *http.Request, errs []FieldError, httpStatus int) error {
+	return api.ValidationErrorWithApiStatus(w, r, errs, httpStatus, 0)
+}
+
+// write `{api_status, errors: [{field, code, message, params}, ...]}` with http-status-code and api-status-code,
+// encoded with the Serializer negotiated from r's Accept header,
+// falling back to JSON if that Serializer can't encode the body. It
+// returns the marshal error instead of panicking, so a broken
+// Serializer cannot crash the process.
+func (api *Api) ValidationErrorWithApiStatus(w http.ResponseWriter, r *http.Request, errs []FieldError, httpStatus, apiStatus int) error {
+	apiErrors := &APIFieldErrors{ApiStatus: apiStatus}
+
+	for _, err := range errs {
+		api.Logger.Errorf("%s", err.Error())
+		apiErrors.Errors = append(apiErrors.Errors, &APIFieldError{
+			Field:   err.Field,
+			Code:    err.Code,
+			Message: err.Message,
+			Params:  err.Params,
+		})
+	}
+
+	j, s, marchalError := api.marshalBody(api.negotiate(r), apiErrors)
+
+	if marchalError != nil {
+		return marchalError
+	}
+
+	w.Header().Set("Content-Type", s.ContentType())
+	http.Error(w, string(j), httpStatus)
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidationErrorLogsMessageContainingPercentVerbatim(t *testing.T) {
+	api := NewApi(nil)
+	logger := &fakeLogger{}
+	api.Logger = logger
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+
+	errs := []FieldError{{Field: "discount", Code: "invalid", Message: "discount '50% off' is invalid"}}
+
+	if err := api.ValidationError(w, r, errs, 422); err != nil {
+		t.Fatalf("ValidationError returned %v", err)
+	}
+
+	if len(logger.lines) != 1 || !strings.Contains(logger.lines[0], "50% off") {
+		t.Errorf("log lines %v do not contain the original message verbatim", logger.lines)
+	}
+}
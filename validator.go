@@ -0,0 +1,27 @@
+package api
+
+import (
+	"fmt"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// FieldErrorsFromValidator adapts a go-playground/validator error list
+// into []FieldError, so handlers using that package can pass its
+// result straight to ValidationError. validator.FieldError (the
+// element type of ValidationErrors) has no Error() method of its own,
+// so the message is built from its Field/Tag.
+func FieldErrorsFromValidator(errs validator.ValidationErrors) []FieldError {
+	fieldErrs := make([]FieldError, 0, len(errs))
+
+	for _, e := range errs {
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   e.Field(),
+			Code:    e.Tag(),
+			Message: fmt.Sprintf("%s failed on the '%s' tag", e.Field(), e.Tag()),
+			Params:  map[string]interface{}{"param": e.Param()},
+		})
+	}
+
+	return fieldErrs
+}